@@ -0,0 +1,31 @@
+package quickwit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moonrhythm/quickwit"
+)
+
+func TestIngestSync(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Path; got != "/api/v1/test/ingest" {
+			t.Errorf("path = %q, want /api/v1/test/ingest", got)
+		}
+		if got := r.URL.Query().Get("commit"); got != "wait_for" {
+			t.Errorf("commit query param = %q, want wait_for", got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := quickwit.NewClient(srv.URL)
+	c.SetCommitMode(quickwit.CommitModeWaitFor)
+
+	err := c.IngestSync(context.Background(), "test", map[string]any{"s": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+package quickwit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects how ingest request bodies are encoded on the wire.
+type Compression int
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionZstd
+)
+
+// compressor compresses an NDJSON batch body and reports the
+// Content-Encoding it produces. Implementations reuse their underlying
+// encoder across calls to avoid allocating per flush.
+type compressor interface {
+	compress(dst *bytes.Buffer, data []byte) error
+	contentEncoding() string
+}
+
+func newCompressor(c Compression) compressor {
+	switch c {
+	case CompressionGzip:
+		return &gzipCompressor{}
+	case CompressionZstd:
+		return &zstdCompressor{}
+	default:
+		return nil
+	}
+}
+
+type gzipCompressor struct {
+	pool sync.Pool // *gzip.Writer
+}
+
+func (c *gzipCompressor) contentEncoding() string { return "gzip" }
+
+func (c *gzipCompressor) compress(dst *bytes.Buffer, data []byte) error {
+	w, _ := c.pool.Get().(*gzip.Writer)
+	if w == nil {
+		w = gzip.NewWriter(dst)
+	} else {
+		w.Reset(dst)
+	}
+	defer c.pool.Put(w)
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+type zstdCompressor struct {
+	pool sync.Pool // *zstd.Encoder
+}
+
+func (c *zstdCompressor) contentEncoding() string { return "zstd" }
+
+func (c *zstdCompressor) compress(dst *bytes.Buffer, data []byte) error {
+	w, _ := c.pool.Get().(*zstd.Encoder)
+	if w == nil {
+		enc, err := zstd.NewWriter(dst)
+		if err != nil {
+			return err
+		}
+		w = enc
+	} else {
+		w.Reset(dst)
+	}
+	defer c.pool.Put(w)
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
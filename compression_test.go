@@ -0,0 +1,44 @@
+package quickwit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func sampleNDJSON() []byte {
+	var buf bytes.Buffer
+	line := `{"s":"test","i":0,"t":"2024-01-01T00:00:00Z","msg":"hello world, this is a sample log line"}` + "\n"
+	buf.WriteString(strings.Repeat(line, 1000))
+	return buf.Bytes()
+}
+
+func BenchmarkGzipCompress(b *testing.B) {
+	data := sampleNDJSON()
+	c := newCompressor(CompressionGzip)
+	var dst bytes.Buffer
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		dst.Reset()
+		if err := c.compress(&dst, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkZstdCompress(b *testing.B) {
+	data := sampleNDJSON()
+	c := newCompressor(CompressionZstd)
+	var dst bytes.Buffer
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		dst.Reset()
+		if err := c.compress(&dst, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
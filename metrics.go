@@ -0,0 +1,118 @@
+package quickwit
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics receives instrumentation events from the ingest pipeline. It is
+// intentionally small so non-Prometheus collectors (StatsD, OpenTelemetry,
+// etc.) can implement it directly. Use SetMetrics to wire up the default
+// Prometheus implementation instead of implementing Metrics yourself.
+type Metrics interface {
+	// IncRecords counts n records accepted into index's buffer.
+	IncRecords(index string, n int)
+	// IncBatches counts one flushed batch for index with the given
+	// status, "ok" or "error".
+	IncBatches(index, status string)
+	// ObserveFlushDuration records how long a flush request to index took.
+	ObserveFlushDuration(index string, d time.Duration)
+	// SetBufferDepth reports the current number of records queued in the
+	// in-memory ingest buffer.
+	SetBufferDepth(n int)
+	// IncDropped counts n records permanently lost for reason, e.g.
+	// "discard" or "flush_error".
+	IncDropped(reason string, n int)
+}
+
+// SetMetricsCollector sets the Metrics implementation used to instrument
+// the ingest pipeline. Use SetMetrics instead to wire up the default
+// Prometheus implementation. Unlike SetSpillDir or SetCompression,
+// SetMetricsCollector may be called at any time, including after Ingest:
+// every instrumentation point re-reads the current collector rather than
+// caching it at setup.
+func (c *Client) SetMetricsCollector(m Metrics) {
+	c.metrics = m
+}
+
+// SetMetrics registers the default Prometheus instrumentation
+// (quickwit_ingest_records_total, quickwit_ingest_batches_total,
+// quickwit_ingest_flush_duration_seconds, quickwit_ingest_buffer_depth,
+// quickwit_ingest_dropped_total) on reg and uses it for this Client. Like
+// SetMetricsCollector, it may be called at any time.
+func (c *Client) SetMetrics(reg prometheus.Registerer) {
+	c.metrics = newPrometheusMetrics(reg)
+}
+
+func (c *Client) getMetrics() Metrics {
+	if c.metrics == nil {
+		return noopMetrics{}
+	}
+	return c.metrics
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) IncRecords(string, int)                    {}
+func (noopMetrics) IncBatches(string, string)                 {}
+func (noopMetrics) ObserveFlushDuration(string, time.Duration) {}
+func (noopMetrics) SetBufferDepth(int)                        {}
+func (noopMetrics) IncDropped(string, int)                    {}
+
+type prometheusMetrics struct {
+	records       *prometheus.CounterVec
+	batches       *prometheus.CounterVec
+	flushDuration *prometheus.HistogramVec
+	bufferDepth   prometheus.Gauge
+	dropped       *prometheus.CounterVec
+}
+
+func newPrometheusMetrics(reg prometheus.Registerer) *prometheusMetrics {
+	m := &prometheusMetrics{
+		records: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickwit_ingest_records_total",
+			Help: "Total number of records accepted into the ingest buffer.",
+		}, []string{"index"}),
+		batches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickwit_ingest_batches_total",
+			Help: "Total number of ingest batches flushed, by status.",
+		}, []string{"index", "status"}),
+		flushDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "quickwit_ingest_flush_duration_seconds",
+			Help:    "Duration of ingest flush HTTP requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"index"}),
+		bufferDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "quickwit_ingest_buffer_depth",
+			Help: "Current number of records queued in the in-memory ingest buffer.",
+		}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickwit_ingest_dropped_total",
+			Help: "Total number of records permanently dropped, by reason.",
+		}, []string{"reason"}),
+	}
+
+	reg.MustRegister(m.records, m.batches, m.flushDuration, m.bufferDepth, m.dropped)
+	return m
+}
+
+func (m *prometheusMetrics) IncRecords(index string, n int) {
+	m.records.WithLabelValues(index).Add(float64(n))
+}
+
+func (m *prometheusMetrics) IncBatches(index, status string) {
+	m.batches.WithLabelValues(index, status).Inc()
+}
+
+func (m *prometheusMetrics) ObserveFlushDuration(index string, d time.Duration) {
+	m.flushDuration.WithLabelValues(index).Observe(d.Seconds())
+}
+
+func (m *prometheusMetrics) SetBufferDepth(n int) {
+	m.bufferDepth.Set(float64(n))
+}
+
+func (m *prometheusMetrics) IncDropped(reason string, n int) {
+	m.dropped.WithLabelValues(reason).Add(float64(n))
+}
@@ -0,0 +1,58 @@
+package quickwit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moonrhythm/quickwit"
+)
+
+type fakeMetrics struct {
+	mu      sync.Mutex
+	records int
+	batches map[string]int
+}
+
+func (m *fakeMetrics) IncRecords(index string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records += n
+}
+
+func (m *fakeMetrics) IncBatches(index, status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.batches == nil {
+		m.batches = map[string]int{}
+	}
+	m.batches[status]++
+}
+
+func (m *fakeMetrics) ObserveFlushDuration(index string, d time.Duration) {}
+func (m *fakeMetrics) SetBufferDepth(n int)                               {}
+func (m *fakeMetrics) IncDropped(reason string, n int)                    {}
+
+func TestMetricsCollector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := &fakeMetrics{}
+	c := quickwit.NewClient(srv.URL)
+	c.SetMetricsCollector(m)
+	c.Ingest("test", map[string]any{"s": "test"})
+	c.Close()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.records != 1 {
+		t.Errorf("records = %d, want 1", m.records)
+	}
+	if m.batches["ok"] != 1 {
+		t.Errorf("batches[ok] = %d, want 1", m.batches["ok"])
+	}
+}
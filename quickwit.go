@@ -3,8 +3,11 @@ package quickwit
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
+	"math"
+	"math/rand"
 	"net/http"
 	"strings"
 	"sync"
@@ -15,23 +18,50 @@ const (
 	IngestBufferSize = 10000
 	IngestBatchSize  = 1000
 	IngestMaxDelay   = time.Second
+
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoffCap  = 30 * time.Second
 )
 
+// Record pairs a document with the index it should be ingested into, for
+// callers that need to fan a single call out across multiple indexes.
+type Record struct {
+	Index string `json:"index"`
+	Doc   any    `json:"doc"`
+}
+
 type Client struct {
 	client           *http.Client
 	auth             func(req *http.Request)
-	endpoint         string // http://{host}/api/v1/{index_name}
+	baseURL          string // http://{host}/api/v1
 	batchSize        int
 	maxDelay         time.Duration
 	ingestBufferSize int
 	discard          bool
-	ingestBuffer     chan any
+	maxRetries       int
+	retryBackoff     time.Duration
+	onError          func(index string, batch []any, err error)
+	spillDir         string
+	spillMaxBytes    int64
+	spill            *spillBuffer
+	compression      Compression
+	compressor       compressor
+	commitMode       CommitMode
+	metrics          Metrics
+	ingestBuffer     chan Record
+	stop             chan struct{}
+	wg               sync.WaitGroup
 	onceSetup        sync.Once
+	flushLocks       sync.Map // index (string) -> *sync.Mutex
 }
 
-func NewClient(endpoint string) *Client {
+// NewClient returns a Client that ingests into host, e.g.
+// "http://localhost:7280". The index is selected per call via Ingest or
+// IngestRecord, so a single Client can fan out to any number of indexes.
+func NewClient(host string) *Client {
 	return &Client{
-		endpoint: endpoint,
+		baseURL: strings.TrimSuffix(host, "/") + "/api/v1",
 	}
 }
 
@@ -59,6 +89,49 @@ func (c *Client) SetDiscard(discard bool) {
 	c.discard = discard
 }
 
+// SetMaxRetries sets the number of times a failed batch is retried before
+// it is handed to OnError. Retries use exponential backoff with jitter,
+// see SetRetryBackoff.
+func (c *Client) SetMaxRetries(maxRetries int) {
+	c.maxRetries = maxRetries
+}
+
+// SetRetryBackoff sets the base duration used to compute the exponential
+// backoff between retries: base * 2^attempt, capped and jittered.
+func (c *Client) SetRetryBackoff(backoff time.Duration) {
+	c.retryBackoff = backoff
+}
+
+// SetOnError sets a callback invoked when a batch for index could not be
+// delivered after exhausting all retries. The batch is dropped after the
+// callback returns.
+func (c *Client) SetOnError(onError func(index string, batch []any, err error)) {
+	c.onError = onError
+}
+
+// SetSpillDir enables the on-disk overflow buffer. When the in-memory
+// ingestBuffer is full, records are appended to segment files under dir
+// instead of being blocked-on or discarded, and are replayed back into
+// the pipeline once the server catches up. Segments left over from a
+// previous process in dir are drained on startup. Must be called before
+// the first call to Ingest.
+func (c *Client) SetSpillDir(dir string) {
+	c.spillDir = dir
+}
+
+// SetSpillMaxBytes sets the size at which an active spill segment is
+// rotated. Defaults to DefaultSpillMaxBytes.
+func (c *Client) SetSpillMaxBytes(n int64) {
+	c.spillMaxBytes = n
+}
+
+// SetCompression sets how ingest request bodies are encoded on the wire.
+// Defaults to CompressionNone. Must be called before the first call to
+// Ingest.
+func (c *Client) SetCompression(compression Compression) {
+	c.compression = compression
+}
+
 func (c *Client) httpClient() *http.Client {
 	if c.client == nil {
 		return http.DefaultClient
@@ -80,104 +153,264 @@ func (c *Client) getBatchSize() int {
 	return c.batchSize
 }
 
+func (c *Client) getMaxRetries() int {
+	if c.maxRetries <= 0 {
+		return DefaultMaxRetries
+	}
+	return c.maxRetries
+}
+
+func (c *Client) getRetryBackoff() time.Duration {
+	if c.retryBackoff <= 0 {
+		return DefaultRetryBackoff
+	}
+	return c.retryBackoff
+}
+
+// backoffWithJitter computes base * 2^attempt, capped at maxRetryBackoffCap,
+// with full jitter applied.
+func backoffWithJitter(base time.Duration, attempt int) time.Duration {
+	d := float64(base) * math.Pow(2, float64(attempt))
+	if d > float64(maxRetryBackoffCap) {
+		d = float64(maxRetryBackoffCap)
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
 func (c *Client) doAuth(req *http.Request) {
 	if c.auth != nil {
 		c.auth(req)
 	}
 }
 
-// Ingest sends data to the quickwit server.
+// Ingest sends data to index on the quickwit server.
 // The data can be any type, and will be marshalled to JSON.
 // The data will be buffered until the buffer is full, then sent to the server.
 // If the buffer is full, Ingest will block until the buffer is no longer full.
-func (c *Client) Ingest(data ...any) {
+func (c *Client) Ingest(index string, data ...any) {
 	c.onceSetup.Do(c.setup)
 	for _, x := range data {
-		if c.discard {
-			select {
-			case c.ingestBuffer <- x:
-			default:
+		c.push(Record{Index: index, Doc: x})
+	}
+}
+
+// IngestRecord sends pre-tagged records to the quickwit server, allowing a
+// single call to fan out across multiple indexes.
+func (c *Client) IngestRecord(records ...Record) {
+	c.onceSetup.Do(c.setup)
+	for _, r := range records {
+		c.push(r)
+	}
+}
+
+func (c *Client) push(r Record) {
+	m := c.getMetrics()
+
+	if c.spill != nil {
+		select {
+		case c.ingestBuffer <- r:
+			m.IncRecords(r.Index, 1)
+		default:
+			if err := c.spill.write(r); err != nil {
+				slog.Error("quickwit: spill write failed", "error", err)
+				m.IncDropped("spill_error", 1)
+			} else {
+				m.IncRecords(r.Index, 1)
 			}
-		} else {
-			c.ingestBuffer <- x
 		}
+		return
+	}
+
+	if c.discard {
+		select {
+		case c.ingestBuffer <- r:
+			m.IncRecords(r.Index, 1)
+		default:
+			m.IncDropped("discard", 1)
+		}
+	} else {
+		c.ingestBuffer <- r
+		m.IncRecords(r.Index, 1)
 	}
 }
 
+// Close stops accepting new writes to the in-memory pipeline and blocks
+// until the final flush of every index's buffer has completed.
 func (c *Client) Close() {
+	if c.stop != nil {
+		close(c.stop)
+	}
 	close(c.ingestBuffer)
+	c.wg.Wait()
+	if c.spill != nil {
+		if err := c.spill.close(); err != nil {
+			slog.Error("quickwit: close spill segment", "error", err)
+		}
+	}
 }
 
 func (c *Client) setup() {
 	if c.ingestBufferSize <= 0 {
 		c.ingestBufferSize = IngestBufferSize
 	}
-	c.ingestBuffer = make(chan any, c.ingestBufferSize)
-	go c.loop()
+	c.ingestBuffer = make(chan Record, c.ingestBufferSize)
+	c.compressor = newCompressor(c.compression)
+
+	if c.spillDir != "" {
+		spill, err := newSpillBuffer(c.spillDir, c.spillMaxBytes)
+		if err != nil {
+			slog.Error("quickwit: spill buffer disabled", "error", err)
+		} else {
+			c.spill = spill
+			c.stop = make(chan struct{})
+			c.wg.Add(1)
+			go func() {
+				defer c.wg.Done()
+				c.spill.drain(c.stop, c.ingestBuffer)
+			}()
+		}
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.loop()
+	}()
 }
 
 func (c *Client) loop() {
-	var buf bytes.Buffer
-	jsonEnc := json.NewEncoder(&buf)
-
 	batchSize := c.getBatchSize()
-	buffer := make([]any, 0, batchSize)
-
-	endpoint := c.endpoint
-	endpoint = strings.TrimSuffix(endpoint, "/")
-	endpoint = endpoint + "/ingest"
+	buffers := map[string][]any{}
 
-	flush := func() {
-		if len(buffer) == 0 {
+	// flush hands the buffered batch for index off to its own goroutine,
+	// so a slow or down index can't stall the event loop and, with it,
+	// buffering and flushing for every other index sharing this client.
+	// Flushes for the same index are serialized via indexLock; the order in
+	// which queued batches for one index are sent is not guaranteed.
+	flush := func(index string) {
+		batch := buffers[index]
+		if len(batch) == 0 {
 			return
 		}
+		buffers[index] = nil
 
-		buf.Reset()
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			lock := c.indexLock(index)
+			lock.Lock()
+			defer lock.Unlock()
+			c.flushBatch(index, batch)
+		}()
+	}
 
-		for _, x := range buffer {
-			jsonEnc.Encode(x)
-			buf.WriteString("\n")
+	flushAll := func() {
+		for index := range buffers {
+			flush(index)
 		}
+	}
+
+	ticker := time.NewTicker(c.getMaxDelay())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			flushAll()
+		case r, ok := <-c.ingestBuffer:
+			if !ok { // channel closed
+				flushAll()
+				return
+			}
+			batch := append(buffers[r.Index], r.Doc)
+			buffers[r.Index] = batch
+			c.getMetrics().SetBufferDepth(len(c.ingestBuffer))
+			if len(batch) >= batchSize {
+				flush(r.Index)
+			}
+		}
+	}
+}
+
+// indexLock returns the mutex serializing flushes for index, so that two
+// concurrently flushed batches for the same index are never in flight
+// at once. The mutex does not order waiters, so batches waiting on it may
+// be sent in a different order than they were handed off.
+func (c *Client) indexLock(index string) *sync.Mutex {
+	v, _ := c.flushLocks.LoadOrStore(index, &sync.Mutex{})
+	return v.(*sync.Mutex)
+}
+
+// flushBatch marshals batch to NDJSON, compresses it if configured, and
+// sends it to index with retry/backoff, recording metrics and invoking
+// OnError if every retry is exhausted. It performs its own HTTP round
+// trip independent of any other index's flush.
+func (c *Client) flushBatch(index string, batch []any) {
+	metrics := c.getMetrics()
 
-		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(buf.Bytes()))
+	var buf bytes.Buffer
+	jsonEnc := json.NewEncoder(&buf) // Encode already terminates each line with "\n"
+	for _, x := range batch {
+		jsonEnc.Encode(x)
+	}
+
+	send := func() error {
+		reqBody := buf.Bytes()
+		if c.compressor != nil {
+			var body bytes.Buffer
+			if err := c.compressor.compress(&body, buf.Bytes()); err != nil {
+				return err
+			}
+			reqBody = body.Bytes()
+		}
+
+		req, err := http.NewRequest(http.MethodPost, c.ingestURL(index), bytes.NewReader(reqBody))
 		if err != nil {
-			panic(err)
-			return
+			return err
+		}
+		if c.compressor != nil {
+			req.Header.Set("Content-Encoding", c.compressor.contentEncoding())
 		}
 		c.doAuth(req)
 
 		resp, err := c.httpClient().Do(req)
 		if err != nil {
-			return
+			return err
 		}
 		io.Copy(io.Discard, resp.Body)
 		resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
-			slog.Error("quickwit: ingest status not ok", "status", resp.Status)
-			return
+			return fmt.Errorf("quickwit: ingest status not ok: %s", resp.Status)
 		}
-
-		buffer = buffer[:0]
+		return nil
 	}
 
-	ticker := time.NewTicker(c.getMaxDelay())
+	maxRetries := c.getMaxRetries()
+	backoff := c.getRetryBackoff()
 
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				flush()
-			case x, ok := <-c.ingestBuffer:
-				if !ok { // channel closed
-					flush()
-					return
-				}
-				buffer = append(buffer, x)
-				if len(buffer) >= batchSize {
-					flush()
-				}
-			}
+	start := time.Now()
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffWithJitter(backoff, attempt-1))
 		}
-	}()
+		if err = send(); err == nil {
+			break
+		}
+		slog.Error("quickwit: ingest failed", "index", index, "attempt", attempt, "error", err)
+	}
+	metrics.ObserveFlushDuration(index, time.Since(start))
+
+	if err != nil {
+		metrics.IncBatches(index, "error")
+		metrics.IncDropped("flush_error", len(batch))
+		if c.onError != nil {
+			dropped := make([]any, len(batch))
+			copy(dropped, batch)
+			c.onError(index, dropped, err)
+		}
+		return
+	}
+	metrics.IncBatches(index, "ok")
 }
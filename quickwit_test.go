@@ -8,8 +8,8 @@ import (
 )
 
 func TestClient(t *testing.T) {
-	c := quickwit.NewClient("http://localhost:7280/api/v1/test")
-	c.Ingest(map[string]any{
+	c := quickwit.NewClient("http://localhost:7280")
+	c.Ingest("test", map[string]any{
 		"s": "test",
 		"i": 0,
 		"t": time.Now().Format(time.RFC3339),
@@ -0,0 +1,139 @@
+// Package quickwitslog provides a log/slog.Handler that ships records to
+// Quickwit via a quickwit.Client.
+package quickwitslog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+
+	"github.com/moonrhythm/quickwit"
+)
+
+// HandlerOptions configures a Handler.
+type HandlerOptions struct {
+	// Index is the quickwit index records are ingested into.
+	Index string
+
+	// Level reports the minimum record level that will be logged.
+	// The default is slog.LevelInfo.
+	Level slog.Leveler
+
+	// AddSource causes the handler to compute the source code position
+	// of the log statement and add it to the record as "source".
+	AddSource bool
+}
+
+// Handler is a slog.Handler that marshals records to a JSON document and
+// hands it to a quickwit.Client's Ingest method.
+//
+// WithAttrs and WithGroup are pre-computed on the returned Handler: attrs
+// from WithAttrs are stored with the current group prefix already applied
+// to their keys, and WithGroup extends a dotted group prefix. Group-valued
+// attrs are flattened into the document by Handle, for every record.
+type Handler struct {
+	client *quickwit.Client
+	opts   HandlerOptions
+
+	attrs       []slog.Attr // attrs from WithAttrs, keys already prefixed
+	groupPrefix string      // dotted prefix from WithGroup, e.g. "req.http."
+}
+
+// NewHandler returns a Handler that ingests records into client.
+func NewHandler(client *quickwit.Client, opts HandlerOptions) *Handler {
+	if opts.Level == nil {
+		opts.Level = slog.LevelInfo
+	}
+	return &Handler{client: client, opts: opts}
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	doc := make(map[string]any, 4+len(h.attrs)+r.NumAttrs())
+	doc["time"] = r.Time
+	doc["level"] = r.Level.String()
+	doc["msg"] = r.Message
+
+	if h.opts.AddSource && r.PC != 0 {
+		if src := source(r.PC); src != "" {
+			doc["source"] = src
+		}
+	}
+
+	for _, a := range h.attrs {
+		flattenAttr(doc, a)
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		if h.groupPrefix != "" {
+			a.Key = h.groupPrefix + a.Key
+		}
+		flattenAttr(doc, a)
+		return true
+	})
+
+	h.client.Ingest(h.opts.Index, doc)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	nh := *h
+	nh.attrs = make([]slog.Attr, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(nh.attrs, h.attrs)
+	for _, a := range attrs {
+		if h.groupPrefix != "" {
+			a.Key = h.groupPrefix + a.Key
+		}
+		nh.attrs = append(nh.attrs, a)
+	}
+	return &nh
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	nh := *h
+	nh.groupPrefix = h.groupPrefix + name + "."
+	return &nh
+}
+
+// flattenAttr writes a into doc, recursing into group-valued attrs so
+// that e.g. slog.Group("req", slog.String("method", "GET")) becomes the
+// key "req.method".
+func flattenAttr(doc map[string]any, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		for _, sub := range a.Value.Group() {
+			if a.Key != "" {
+				sub.Key = a.Key + "." + sub.Key
+			}
+			flattenAttr(doc, sub)
+		}
+		return
+	}
+
+	doc[a.Key] = a.Value.Any()
+}
+
+func source(pc uintptr) string {
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+	if f.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", f.File, f.Line)
+}
@@ -0,0 +1,73 @@
+package quickwitslog_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/moonrhythm/quickwit"
+	"github.com/moonrhythm/quickwit/quickwitslog"
+)
+
+func TestHandlerDocumentShape(t *testing.T) {
+	docs := make(chan map[string]any, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var doc map[string]any
+			if err := json.Unmarshal(sc.Bytes(), &doc); err != nil {
+				t.Errorf("decode ingested doc: %v", err)
+				continue
+			}
+			docs <- doc
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := quickwit.NewClient(srv.URL)
+	h := quickwitslog.NewHandler(c, quickwitslog.HandlerOptions{Index: "test", Level: slog.LevelDebug})
+
+	// "service" is added before any group; "method" is added after
+	// WithGroup("req") but before WithGroup("http"); the record's own
+	// attrs (including a nested slog.Group) are added last and should
+	// pick up both group prefixes.
+	log := slog.New(h).
+		With("service", "test").
+		WithGroup("req").
+		With("method", "GET").
+		WithGroup("http")
+	log.Info("hello", "trace", "abc", slog.Group("resp", slog.Int("status", 200)))
+	c.Close()
+
+	var doc map[string]any
+	select {
+	case doc = <-docs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no document was ingested")
+	}
+
+	if doc["msg"] != "hello" {
+		t.Errorf("msg = %v, want %q", doc["msg"], "hello")
+	}
+	if doc["level"] != "INFO" {
+		t.Errorf("level = %v, want %q", doc["level"], "INFO")
+	}
+	if doc["service"] != "test" {
+		t.Errorf("service = %v, want %q", doc["service"], "test")
+	}
+	if doc["req.method"] != "GET" {
+		t.Errorf("req.method = %v, want %q", doc["req.method"], "GET")
+	}
+	if doc["req.http.trace"] != "abc" {
+		t.Errorf("req.http.trace = %v, want %q", doc["req.http.trace"], "abc")
+	}
+	if doc["req.http.resp.status"] != float64(200) {
+		t.Errorf("req.http.resp.status = %v, want 200", doc["req.http.resp.status"])
+	}
+}
@@ -0,0 +1,81 @@
+package quickwit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moonrhythm/quickwit"
+)
+
+func TestRetrySucceedsAfterFailures(t *testing.T) {
+	var requests atomic.Int32
+	const failures = 2
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if n <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := quickwit.NewClient(srv.URL)
+	c.SetMaxRetries(failures + 1)
+	c.SetRetryBackoff(time.Millisecond)
+	c.SetOnError(func(index string, batch []any, err error) {
+		t.Errorf("OnError called unexpectedly: %v", err)
+	})
+
+	c.Ingest("test", map[string]any{"s": "test"})
+	c.Close()
+
+	if got := requests.Load(); got != failures+1 {
+		t.Errorf("requests = %d, want %d", got, failures+1)
+	}
+}
+
+func TestRetryExhaustedCallsOnError(t *testing.T) {
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	const maxRetries = 2
+
+	var gotErr error
+	var gotBatch []any
+	onErrorCalls := 0
+
+	c := quickwit.NewClient(srv.URL)
+	c.SetMaxRetries(maxRetries)
+	c.SetRetryBackoff(time.Millisecond)
+	c.SetOnError(func(index string, batch []any, err error) {
+		onErrorCalls++
+		gotErr = err
+		gotBatch = batch
+	})
+
+	c.Ingest("test", map[string]any{"s": "test"})
+	c.Close()
+
+	if got := requests.Load(); got != maxRetries+1 {
+		t.Errorf("requests = %d, want %d", got, maxRetries+1)
+	}
+	if onErrorCalls != 1 {
+		t.Fatalf("OnError called %d times, want 1", onErrorCalls)
+	}
+	if gotErr == nil {
+		t.Error("OnError err = nil, want non-nil")
+	}
+	if len(gotBatch) != 1 {
+		t.Errorf("OnError batch len = %d, want 1", len(gotBatch))
+	}
+}
@@ -0,0 +1,183 @@
+package quickwit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSpillMaxBytes is the segment rotation size used when
+// SetSpillMaxBytes is not called.
+const DefaultSpillMaxBytes = 64 << 20 // 64MiB
+
+// spillBuffer is an append-only, segment-based overflow buffer backed by
+// files on disk. Records that don't fit in the in-memory ingestBuffer are
+// appended to the active segment; a background drainer feeds them back
+// into the in-memory pipeline once there's room. Segments are plain
+// newline-delimited JSON so they can be replayed after a crash.
+type spillBuffer struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	w       *os.File
+	written int64
+}
+
+func newSpillBuffer(dir string, maxBytes int64) (*spillBuffer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("quickwit: create spill dir: %w", err)
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultSpillMaxBytes
+	}
+	return &spillBuffer{dir: dir, maxBytes: maxBytes}, nil
+}
+
+func (s *spillBuffer) segmentPath() string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d.ndjson", time.Now().UnixNano()))
+}
+
+// write appends r to the active segment, rotating to a new segment once
+// maxBytes is reached.
+func (s *spillBuffer) write(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == nil {
+		f, err := os.Create(s.segmentPath())
+		if err != nil {
+			return fmt.Errorf("quickwit: create spill segment: %w", err)
+		}
+		s.w = f
+		s.written = 0
+	}
+
+	n, err := s.w.Write(b)
+	s.written += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if s.written >= s.maxBytes {
+		s.w.Close()
+		s.w = nil
+	}
+	return nil
+}
+
+// close flushes and closes the active segment, if any.
+func (s *spillBuffer) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == nil {
+		return nil
+	}
+	err := s.w.Close()
+	s.w = nil
+	return err
+}
+
+// segments returns the on-disk segment files in creation order, including
+// segments left over from a previous process.
+func (s *spillBuffer) segments() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// drain periodically reads records out of the oldest segments and pushes
+// them to out, blocking when out is full so that draining naturally paces
+// itself to the rate the server can absorb. The active
+// (still-being-written) segment is skipped until it is rotated away.
+// It runs until stop is closed.
+func (s *spillBuffer) drain(stop <-chan struct{}, out chan<- Record) {
+	const pollInterval = time.Second
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		segments, err := s.segments()
+		if err != nil {
+			slog.Error("quickwit: list spill segments", "error", err)
+		}
+
+		for _, path := range segments {
+			s.mu.Lock()
+			active := s.w != nil && s.w.Name() == path
+			s.mu.Unlock()
+			if active {
+				continue
+			}
+
+			if !s.drainSegment(path, stop, out) {
+				return
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *spillBuffer) drainSegment(path string, stop <-chan struct{}, out chan<- Record) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		slog.Error("quickwit: open spill segment", "path", path, "error", err)
+		return true
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			slog.Error("quickwit: decode spill record", "path", path, "error", err)
+			continue
+		}
+
+		select {
+		case out <- r:
+		case <-stop:
+			return false
+		}
+	}
+	if err := sc.Err(); err != nil {
+		// The scan stopped early (e.g. a corrupt or oversized line), so
+		// records after it were never drained. Leave the segment on disk
+		// and retry it on the next poll instead of losing those records.
+		slog.Error("quickwit: read spill segment", "path", path, "error", err)
+		return true
+	}
+
+	if err := os.Remove(path); err != nil {
+		slog.Error("quickwit: remove spill segment", "path", path, "error", err)
+	}
+	return true
+}